@@ -0,0 +1,71 @@
+package conf
+
+import "time"
+
+type authOptions struct {
+	// PasswordResetTokenTTL is how long a password-reset token stays valid after
+	// being issued. Tokens are single-use and are invalidated as soon as they are
+	// consumed or superseded by a newer request.
+	PasswordResetTokenTTL time.Duration
+
+	// IgnoreUnknownUsernames makes AuthenticateByUsername return the same
+	// ErrInvalidCredentials for an unknown username as for a wrong password,
+	// so login attempts can't be used to enumerate valid accounts. Operators
+	// can set this to false to get back the old, more informative errors
+	// while debugging an auth integration.
+	IgnoreUnknownUsernames bool
+
+	// MaxFailedAttempts is how many failed logins within FailureWindow lock
+	// the account out.
+	MaxFailedAttempts int
+	// FailureWindow is the sliding window failed attempts are counted over.
+	// A successful login, or enough time passing without a new failure,
+	// resets the counter.
+	FailureWindow time.Duration
+	// MaxLockDuration caps the exponentially increasing lockout duration
+	// applied each time the account is locked again.
+	MaxLockDuration time.Duration
+}
+
+type passwordPolicyOptions struct {
+	// MinLength and MaxLength are 0 when unset, meaning "no limit".
+	MinLength int
+	MaxLength int
+
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// DisallowUsernameSubstring rejects passwords that contain the account's
+	// own username, case-insensitively.
+	DisallowUsernameSubstring bool
+
+	// BreachedPasswordList, when set, points to a local file listing known
+	// breached password hashes: one uppercase SHA1 hex digest per line, sorted,
+	// each line exactly 40 hex digits followed by "\n". Lines carrying a
+	// ":count" suffix or "\r\n" endings, as in the HIBP Pwned Passwords
+	// downloads, are not supported. Leave empty to skip this check.
+	BreachedPasswordList string
+
+	// HistorySize is how many of a user's previous passwords are remembered
+	// and rejected on reuse. 0 disables the history check entirely.
+	HistorySize int
+}
+
+type configOptions struct {
+	PasswordEncryptionKey string
+	BaseURL               string
+	Auth                  authOptions
+	PasswordPolicy        passwordPolicyOptions
+}
+
+var Server = &configOptions{
+	Auth: authOptions{
+		PasswordResetTokenTTL:  time.Hour,
+		IgnoreUnknownUsernames: true,
+		MaxFailedAttempts:      5,
+		FailureWindow:          15 * time.Minute,
+		MaxLockDuration:        24 * time.Hour,
+	},
+}
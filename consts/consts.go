@@ -0,0 +1,12 @@
+package consts
+
+import "time"
+
+const (
+	// PasswordAutogenPrefix marks a user's stored password as having been generated
+	// automatically (e.g. when using Reverse Proxy Authentication), so it should never
+	// be treated as a real, user-chosen secret.
+	PasswordAutogenPrefix = "__NAVIDROME_AUTOGEN__"
+
+	DefaultSessionTimeout = 24 * time.Hour
+)
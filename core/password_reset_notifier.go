@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+
+	"github.com/navidrome/navidrome/log"
+)
+
+// PasswordResetNotifier delivers a password-reset link to a user. It is
+// deliberately narrow so deployments can plug in whatever email/SMS/webhook
+// integration they already run, without the reset flow itself knowing
+// anything about transports.
+type PasswordResetNotifier interface {
+	Notify(ctx context.Context, email, resetLink string) error
+}
+
+// logPasswordResetNotifier is the default notifier used until an operator
+// wires up a real one. It just logs the link, which is enough for
+// self-hosted single-user setups and for development.
+type logPasswordResetNotifier struct{}
+
+func NewLogPasswordResetNotifier() PasswordResetNotifier {
+	return &logPasswordResetNotifier{}
+}
+
+func (n *logPasswordResetNotifier) Notify(ctx context.Context, email, resetLink string) error {
+	log.Info(ctx, "Password reset requested", "email", email, "link", resetLink)
+	return nil
+}
@@ -0,0 +1,11 @@
+package model
+
+// QueryOptions carries the common pagination/sort/filter knobs accepted by
+// every repository's GetAll/CountAll. Zero value means "no restriction".
+type QueryOptions struct {
+	Sort    string
+	Order   string
+	Max     int
+	Offset  int
+	Filters map[string]any
+}
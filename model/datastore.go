@@ -0,0 +1,9 @@
+package model
+
+import "context"
+
+// DataStore gives handlers access to the repository for each entity without
+// tying them to a specific persistence implementation.
+type DataStore interface {
+	User(ctx context.Context) UserRepository
+}
@@ -0,0 +1,23 @@
+package model
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("data not found")
+	ErrInvalidAuth   = errors.New("invalid login")
+	ErrNotAuthorized = errors.New("not authorized")
+
+	// ErrInvalidCredentials is returned by UserRepository.AuthenticateByUsername
+	// for both an unknown username and a known username with the wrong
+	// password, so callers can't use the error to enumerate valid accounts.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrAccountLocked is returned by UserRepository.AuthenticateByUsername
+	// when the account is locked out after too many failed login attempts.
+	ErrAccountLocked = errors.New("account locked due to too many failed login attempts")
+
+	// ErrInvalidResetToken covers every reason a password-reset token can't be
+	// consumed (unknown, expired or already used), so callers can't tell
+	// which case they hit.
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
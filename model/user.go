@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+type User struct {
+	ID              string     `structs:"id" json:"id"`
+	UserName        string     `structs:"user_name" json:"userName"`
+	Name            string     `structs:"name" json:"name"`
+	Email           string     `structs:"email" json:"email"`
+	NewPassword     string     `structs:"-" json:"password,omitempty"`
+	Password        string     `structs:"password,omitempty" json:"-"`
+	CurrentPassword string     `structs:"-" json:"currentPassword,omitempty"`
+	IsAdmin         bool       `structs:"is_admin" json:"isAdmin"`
+	LastLoginAt     *time.Time `structs:"last_login_at" json:"lastLoginAt"`
+	LastAccessAt    *time.Time `structs:"last_access_at" json:"lastAccessAt"`
+	CreatedAt       time.Time  `structs:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time  `structs:"updated_at" json:"updatedAt"`
+
+	// FailedLoginCount and LockedUntil back the brute-force throttling in
+	// UserRepository.RegisterFailedLogin/ClearFailedLogins/IsLocked. They are
+	// not meant to be set directly by API clients.
+	FailedLoginCount int        `structs:"failed_login_count" json:"-"`
+	FailedLoginAt    *time.Time `structs:"failed_login_at" json:"-"`
+	LockedUntil      *time.Time `structs:"locked_until" json:"lockedUntil,omitempty"`
+}
+
+type Users []User
+
+type UserRepository interface {
+	CountAll(options ...QueryOptions) (int64, error)
+	Get(id string) (*User, error)
+	GetAll(options ...QueryOptions) (Users, error)
+	Put(*User) error
+	Delete(id string) error
+	FindByUsername(username string) (*User, error)
+	FindByUsernameWithPassword(username string) (*User, error)
+	FindByEmail(email string) (*User, error)
+	UpdateLastLoginAt(id string) error
+	UpdateLastAccessAt(id string) error
+
+	// CreatePasswordResetToken issues a new single-use token that can be
+	// exchanged for a password change within ttl. Any previously issued,
+	// still-valid token for the same user is invalidated.
+	CreatePasswordResetToken(userID string, ttl time.Duration) (string, error)
+	// ConsumePasswordResetToken atomically marks a token as used and returns
+	// the user it was issued for. It fails if the token is unknown, expired
+	// or already used.
+	ConsumePasswordResetToken(token string) (*User, error)
+
+	// AuthenticateByUsername checks username/password and returns the
+	// matching user. It always fails with ErrInvalidCredentials (unless
+	// Auth.IgnoreUnknownUsernames is disabled), whether the username doesn't
+	// exist or the password is wrong, and takes the same time either way.
+	// A known username whose account is locked out fails with
+	// ErrAccountLocked instead, whatever the password.
+	AuthenticateByUsername(username, password string) (*User, error)
+
+	// RegisterFailedLogin records a failed login attempt for userID, locking
+	// the account once Auth.MaxFailedAttempts is reached within
+	// Auth.FailureWindow, for an exponentially increasing duration capped at
+	// Auth.MaxLockDuration.
+	RegisterFailedLogin(userID string) error
+	// ClearFailedLogins resets a user's failed-login counter and lifts any
+	// lock, as happens automatically after a successful login.
+	ClearFailedLogins(userID string) error
+	// IsLocked reports whether userID is currently locked out, and until when.
+	IsLocked(userID string) (bool, time.Time)
+}
@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// dummyUserID is a well-known, never-assigned user id. touchDummyLoginAccounting
+// runs the same shaped queries RegisterFailedLogin would against it, so an
+// unknown-username attempt costs about as much as a wrong-password one instead
+// of skipping that DB work and becoming a timing oracle for enumeration.
+const dummyUserID = "00000000-0000-0000-0000-000000000000"
+
+func (r *userRepository) touchDummyLoginAccounting() {
+	_, _ = r.Get(dummyUserID)
+	upd := sq.Update(r.tableName).
+		Set("failed_login_count", 0).
+		Set("failed_login_at", time.Now()).
+		Set("locked_until", nil).
+		Where(sq.Eq{"id": dummyUserID})
+	_, _ = r.executeSQL(upd)
+}
+
+// dummyPassword is decrypted and compared against on every login attempt for
+// an unknown username, so that path costs the same as a known username with
+// a wrong password, denying a timing oracle for username enumeration.
+var dummyPassword = sync.OnceValue(func() string {
+	hash, err := encryptPassword("a-fixed-dummy-password-used-only-for-timing")
+	if err != nil {
+		// encryptPassword only fails if PasswordEncryptionKey is misconfigured,
+		// which would already be breaking every other auth path.
+		panic(err)
+	}
+	return hash
+})
+
+func (r *userRepository) AuthenticateByUsername(username, password string) (*model.User, error) {
+	u, err := r.FindByUsernameWithPassword(username)
+	if err != nil {
+		if !errors.Is(err, model.ErrNotFound) {
+			return nil, err
+		}
+		dummy, _ := decryptPassword(dummyPassword())
+		subtle.ConstantTimeCompare([]byte(dummy), []byte(password))
+		r.touchDummyLoginAccounting()
+		if conf.Server.Auth.IgnoreUnknownUsernames {
+			return nil, model.ErrInvalidCredentials
+		}
+		return nil, model.ErrNotFound
+	}
+	if locked, _ := r.IsLocked(u.ID); locked {
+		// Keep registering attempts made against a locked account so the
+		// failure streak (and its escalating lockout duration) can still
+		// advance instead of getting stuck at the first threshold crossed.
+		if err := r.RegisterFailedLogin(u.ID); err != nil {
+			log.Error(r.ctx, "Could not register failed login attempt", "user", u.UserName, err)
+		}
+		return nil, model.ErrAccountLocked
+	}
+	if subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) != 1 {
+		if err := r.RegisterFailedLogin(u.ID); err != nil {
+			log.Error(r.ctx, "Could not register failed login attempt", "user", u.UserName, err)
+		}
+		return nil, model.ErrInvalidCredentials
+	}
+	if err := r.ClearFailedLogins(u.ID); err != nil {
+		log.Error(r.ctx, "Could not clear failed login attempts", "user", u.UserName, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,86 @@
+package persistence
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // HIBP Pwned Passwords uses SHA1 by spec; not used for storage, only lookup.
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/edsrzf/mmap-go"
+)
+
+// breachListRecordLen is the width of one record in the HIBP-style sorted
+// hash file: 40 uppercase hex digits of a SHA1 digest, plus a newline.
+const breachListRecordLen = 41
+
+// breachList is a sorted, fixed-width breached-password-hash file, mmapped so
+// the whole list never has to be read into the Go heap. Lookups binary-search
+// the mapped bytes directly. This is the k-anonymity pattern used by offline
+// HIBP-style breach checks: only a local hash comparison is ever made, the
+// plaintext password never leaves the process and the full list never
+// leaves disk.
+type breachList struct {
+	file *os.File
+	data mmap.MMap
+}
+
+var (
+	breachListsMu sync.Mutex
+	breachLists   = map[string]*breachList{}
+)
+
+// getBreachList returns the mmapped breach list for path, loading and caching
+// it the first time it's requested.
+func getBreachList(path string) (*breachList, error) {
+	breachListsMu.Lock()
+	defer breachListsMu.Unlock()
+
+	if bl, ok := breachLists[path]; ok {
+		return bl, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening breached password list: %w", err)
+	}
+	data, err := mmap.Map(f, mmap.RDONLY, 0)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("mapping breached password list: %w", err)
+	}
+
+	bl := &breachList{file: f, data: data}
+	breachLists[path] = bl
+	return bl, nil
+}
+
+// contains reports whether sha1Hex (an uppercase, hex-encoded SHA1 digest) is
+// present in the list, via binary search over the mmapped, sorted records.
+func (bl *breachList) contains(sha1Hex string) bool {
+	records := len(bl.data) / breachListRecordLen
+	needle := []byte(sha1Hex)
+
+	lo, hi := 0, records-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		start := mid * breachListRecordLen
+		line := bl.data[start : start+breachListRecordLen-1] // strip trailing newline
+		switch bytes.Compare(line, needle) {
+		case 0:
+			return true
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid - 1
+		}
+	}
+	return false
+}
+
+func sha1HexUpper(password string) string {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
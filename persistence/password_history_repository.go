@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/deluan/rest"
+	"github.com/navidrome/navidrome/conf"
+)
+
+const passwordHistoryTable = "password_history"
+
+type passwordHistoryEntry struct {
+	UserID            string    `db:"user_id"`
+	PasswordEncrypted string    `db:"password_encrypted"`
+	CreatedAt         time.Time `db:"created_at"`
+}
+
+// checkPasswordHistory rejects newPassword if it matches the user's current
+// password, or the plaintext of any of their previous HistorySize-1
+// passwords. It is a no-op when history is disabled (HistorySize <= 0).
+func (r *userRepository) checkPasswordHistory(userID, currentEncryptedPassword, newPassword string) error {
+	limit := conf.Server.PasswordPolicy.HistorySize
+	if limit <= 0 {
+		return nil
+	}
+	if currentEncryptedPassword != "" {
+		if plain, err := decryptPassword(currentEncryptedPassword); err == nil && plain == newPassword {
+			return &rest.ValidationError{Errors: rest.ValidationErrors{"password": "ra.validation.password.reused"}}
+		}
+	}
+	sel := sq.Select("*").From(passwordHistoryTable).
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at desc").
+		Limit(uint64(limit - 1))
+	var history []passwordHistoryEntry
+	if err := r.queryAll(sel, &history); err != nil {
+		return err
+	}
+	for _, h := range history {
+		plain, err := decryptPassword(h.PasswordEncrypted)
+		if err != nil {
+			continue
+		}
+		if plain == newPassword {
+			return &rest.ValidationError{Errors: rest.ValidationErrors{"password": "ra.validation.password.reused"}}
+		}
+	}
+	return nil
+}
+
+// recordPasswordHistory appends the password being replaced to the user's
+// history, then trims it back down to HistorySize-1 entries (the current
+// password itself accounts for the remaining slot in the history window).
+func (r *userRepository) recordPasswordHistory(userID, replacedEncryptedPassword string) error {
+	ins := sq.Insert(passwordHistoryTable).
+		Columns("user_id", "password_encrypted", "created_at").
+		Values(userID, replacedEncryptedPassword, time.Now())
+	if _, err := r.executeSQL(ins); err != nil {
+		return err
+	}
+	return r.trimPasswordHistory(userID)
+}
+
+func (r *userRepository) trimPasswordHistory(userID string) error {
+	limit := conf.Server.PasswordPolicy.HistorySize - 1
+	if limit <= 0 {
+		del := sq.Delete(passwordHistoryTable).Where(sq.Eq{"user_id": userID})
+		_, err := r.executeSQL(del)
+		return err
+	}
+	sel := sq.Select("created_at").From(passwordHistoryTable).
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at desc").
+		Limit(uint64(limit))
+	var kept []time.Time
+	if err := r.queryAll(sel, &kept); err != nil {
+		return err
+	}
+	if len(kept) < limit {
+		return nil
+	}
+	cutoff := kept[len(kept)-1]
+	del := sq.Delete(passwordHistoryTable).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Lt{"created_at": cutoff})
+	_, err := r.executeSQL(del)
+	return err
+}
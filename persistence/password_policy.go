@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"strings"
+
+	"github.com/deluan/rest"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/model"
+)
+
+// checkPasswordPolicy evaluates the configured PasswordPolicy against a new
+// password. It is only meant to be called when a password is actually being
+// set; an empty policy (the default) rejects nothing. Rules are checked in a
+// fixed order and it reports the first one violated: password.tooShort, for
+// example, always wins over password.missingDigit rather than being silently
+// clobbered by whichever rule happens to be checked last.
+func checkPasswordPolicy(user *model.User, password string) error {
+	p := conf.Server.PasswordPolicy
+
+	rule := func(key string) error {
+		return &rest.ValidationError{Errors: rest.ValidationErrors{"password": key}}
+	}
+
+	if p.MinLength > 0 && len(password) < p.MinLength {
+		return rule("ra.validation.password.tooShort")
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return rule("ra.validation.password.tooLong")
+	}
+	if p.RequireUpper && !strings.ContainsFunc(password, isUpper) {
+		return rule("ra.validation.password.missingUpper")
+	}
+	if p.RequireLower && !strings.ContainsFunc(password, isLower) {
+		return rule("ra.validation.password.missingLower")
+	}
+	if p.RequireDigit && !strings.ContainsFunc(password, isDigit) {
+		return rule("ra.validation.password.missingDigit")
+	}
+	if p.RequireSymbol && !strings.ContainsFunc(password, isSymbol) {
+		return rule("ra.validation.password.missingSymbol")
+	}
+	if p.DisallowUsernameSubstring && user.UserName != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(user.UserName)) {
+		return rule("ra.validation.password.containsUsername")
+	}
+	if p.BreachedPasswordList != "" {
+		bl, err := getBreachList(p.BreachedPasswordList)
+		if err != nil {
+			return err
+		}
+		if bl.contains(sha1HexUpper(password)) {
+			return rule("ra.validation.password.breached")
+		}
+	}
+
+	return nil
+}
+
+func isUpper(r rune) bool  { return r >= 'A' && r <= 'Z' }
+func isLower(r rune) bool  { return r >= 'a' && r <= 'z' }
+func isDigit(r rune) bool  { return r >= '0' && r <= '9' }
+func isSymbol(r rune) bool { return !isUpper(r) && !isLower(r) && !isDigit(r) }
@@ -0,0 +1,169 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/deluan/rest"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkPasswordPolicy", func() {
+	var user *model.User
+
+	BeforeEach(func() {
+		user = &model.User{UserName: "janedoe"}
+		conf.Server.PasswordPolicy.MinLength = 0
+		conf.Server.PasswordPolicy.MaxLength = 0
+		conf.Server.PasswordPolicy.RequireUpper = false
+		conf.Server.PasswordPolicy.RequireLower = false
+		conf.Server.PasswordPolicy.RequireDigit = false
+		conf.Server.PasswordPolicy.RequireSymbol = false
+		conf.Server.PasswordPolicy.DisallowUsernameSubstring = false
+		conf.Server.PasswordPolicy.BreachedPasswordList = ""
+	})
+
+	ruleFor := func(err error) (string, string) {
+		var verr *rest.ValidationError
+		if !errors.As(err, &verr) {
+			return "", ""
+		}
+		for field, key := range verr.Errors {
+			return field, key
+		}
+		return "", ""
+	}
+
+	It("allows anything when the policy is unset", func() {
+		Expect(checkPasswordPolicy(user, "a")).To(BeNil())
+	})
+
+	It("rejects passwords shorter than MinLength", func() {
+		conf.Server.PasswordPolicy.MinLength = 8
+		Expect(checkPasswordPolicy(user, "sh0rt!")).ToNot(BeNil())
+		field, key := ruleFor(checkPasswordPolicy(user, "sh0rt!"))
+		Expect(field).To(Equal("password"))
+		Expect(key).To(Equal("ra.validation.password.tooShort"))
+		Expect(checkPasswordPolicy(user, "longenough1")).To(BeNil())
+	})
+
+	It("rejects passwords longer than MaxLength", func() {
+		conf.Server.PasswordPolicy.MaxLength = 10
+		_, key := ruleFor(checkPasswordPolicy(user, "waytoolongapassword"))
+		Expect(key).To(Equal("ra.validation.password.tooLong"))
+	})
+
+	It("requires an uppercase letter", func() {
+		conf.Server.PasswordPolicy.RequireUpper = true
+		_, key := ruleFor(checkPasswordPolicy(user, "alllower1"))
+		Expect(key).To(Equal("ra.validation.password.missingUpper"))
+		Expect(checkPasswordPolicy(user, "hasUpper1")).To(BeNil())
+	})
+
+	It("requires a lowercase letter", func() {
+		conf.Server.PasswordPolicy.RequireLower = true
+		_, key := ruleFor(checkPasswordPolicy(user, "ALLUPPER1"))
+		Expect(key).To(Equal("ra.validation.password.missingLower"))
+		Expect(checkPasswordPolicy(user, "hasLower1")).To(BeNil())
+	})
+
+	It("requires a digit", func() {
+		conf.Server.PasswordPolicy.RequireDigit = true
+		_, key := ruleFor(checkPasswordPolicy(user, "NoDigitsHere"))
+		Expect(key).To(Equal("ra.validation.password.missingDigit"))
+		Expect(checkPasswordPolicy(user, "HasDigit1")).To(BeNil())
+	})
+
+	It("requires a symbol", func() {
+		conf.Server.PasswordPolicy.RequireSymbol = true
+		_, key := ruleFor(checkPasswordPolicy(user, "NoSymbols1"))
+		Expect(key).To(Equal("ra.validation.password.missingSymbol"))
+		Expect(checkPasswordPolicy(user, "HasSymbol1!")).To(BeNil())
+	})
+
+	It("rejects the username as a substring, case-insensitively", func() {
+		conf.Server.PasswordPolicy.DisallowUsernameSubstring = true
+		_, key := ruleFor(checkPasswordPolicy(user, "imJaneDoe123"))
+		Expect(key).To(Equal("ra.validation.password.containsUsername"))
+		Expect(checkPasswordPolicy(user, "somethingElse1")).To(BeNil())
+	})
+
+	Context("combining multiple rules", func() {
+		BeforeEach(func() {
+			conf.Server.PasswordPolicy.MinLength = 10
+			conf.Server.PasswordPolicy.RequireDigit = true
+			conf.Server.PasswordPolicy.RequireSymbol = true
+		})
+		It("fails as soon as any rule is violated", func() {
+			Expect(checkPasswordPolicy(user, "short")).ToNot(BeNil())
+		})
+		It("passes when every rule is satisfied", func() {
+			Expect(checkPasswordPolicy(user, "longEnough1!")).To(BeNil())
+		})
+	})
+
+	Context("breached password list", func() {
+		var listPath string
+
+		BeforeEach(func() {
+			dir := GinkgoT().TempDir()
+			listPath = filepath.Join(dir, "breached.txt")
+			hashes := []string{sha1HexUpper("password1"), sha1HexUpper("letmein"), sha1HexUpper("qwerty123")}
+			sort.Strings(hashes)
+			var content string
+			for _, h := range hashes {
+				content += h + "\n"
+			}
+			Expect(os.WriteFile(listPath, []byte(content), 0o644)).To(Succeed())
+			conf.Server.PasswordPolicy.BreachedPasswordList = listPath
+			breachListsMu.Lock()
+			delete(breachLists, listPath)
+			breachListsMu.Unlock()
+		})
+
+		It("rejects a password present in the list", func() {
+			_, key := ruleFor(checkPasswordPolicy(user, "letmein"))
+			Expect(key).To(Equal("ra.validation.password.breached"))
+		})
+
+		It("allows a password absent from the list", func() {
+			Expect(checkPasswordPolicy(user, "not-in-the-list-42")).To(BeNil())
+		})
+	})
+})
+
+func BenchmarkBreachedPasswordCheck(b *testing.B) {
+	dir := b.TempDir()
+	listPath := filepath.Join(dir, "breached.txt")
+
+	hashes := make([]string, 100000)
+	for i := range hashes {
+		hashes[i] = sha1HexUpper(fmt.Sprintf("password-%d", i))
+	}
+	sort.Strings(hashes)
+	var content string
+	for _, h := range hashes {
+		content += h + "\n"
+	}
+	if err := os.WriteFile(listPath, []byte(content), 0o644); err != nil {
+		b.Fatal(err)
+	}
+
+	bl, err := getBreachList(listPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	needle := sha1HexUpper("password-42")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bl.contains(needle)
+	}
+}
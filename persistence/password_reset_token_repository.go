@@ -0,0 +1,80 @@
+package persistence
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/base64"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/navidrome/navidrome/model"
+)
+
+const passwordResetTokenTable = "password_reset_token"
+
+// passwordResetToken mirrors the password_reset_token table. Only a hash of
+// the token is ever persisted; the raw token exists solely in memory long
+// enough to be emailed to the user.
+type passwordResetToken struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    string    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Used      bool      `db:"used"`
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *userRepository) CreatePasswordResetToken(userID string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	// Invalidate any tokens still outstanding for this user before issuing a
+	// new one, so only the most recently requested link/code works.
+	del := sq.Delete(passwordResetTokenTable).Where(sq.Eq{"user_id": userID})
+	if _, err := r.executeSQL(del); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	ins := sq.Insert(passwordResetTokenTable).
+		Columns("token_hash", "user_id", "created_at", "expires_at", "used").
+		Values(hashResetToken(token), userID, now, now.Add(ttl), false)
+	if _, err := r.executeSQL(ins); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (r *userRepository) ConsumePasswordResetToken(token string) (*model.User, error) {
+	hash := hashResetToken(token)
+	sel := sq.Select("*").From(passwordResetTokenTable).Where(sq.Eq{"token_hash": hash})
+	var rec passwordResetToken
+	err := r.queryOne(sel, &rec)
+	if err != nil {
+		return nil, model.ErrInvalidResetToken
+	}
+	if rec.Used || time.Now().After(rec.ExpiresAt) {
+		return nil, model.ErrInvalidResetToken
+	}
+
+	upd := sq.Update(passwordResetTokenTable).Set("used", true).
+		Where(sq.Eq{"token_hash": hash, "used": false})
+	affected, err := r.executeSQL(upd)
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		// Someone else consumed it concurrently between the select and the update.
+		return nil, model.ErrInvalidResetToken
+	}
+
+	return r.Get(rec.UserID)
+}
@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/fatih/structs"
+	"github.com/jmoiron/sqlx"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// sqlRepository holds the bits shared by every table-backed repository: the
+// request-scoped context (used for logging and cancellation), the connection
+// (or transaction) it runs against, and the table it operates on.
+type sqlRepository struct {
+	ctx       context.Context
+	db        sqlx.Ext
+	tableName string
+}
+
+func (r sqlRepository) newSelect(options ...model.QueryOptions) sq.SelectBuilder {
+	q := sq.Select("*").From(r.tableName)
+	for _, o := range options {
+		if o.Max > 0 {
+			q = q.Limit(uint64(o.Max))
+		}
+		if o.Offset > 0 {
+			q = q.Offset(uint64(o.Offset))
+		}
+		if o.Sort != "" {
+			order := o.Sort
+			if o.Order != "" {
+				order += " " + o.Order
+			}
+			q = q.OrderBy(order)
+		}
+	}
+	return q
+}
+
+func (r sqlRepository) queryOne(sel sq.SelectBuilder, dest interface{}) error {
+	query, args, err := sel.ToSql()
+	if err != nil {
+		return err
+	}
+	err = sqlx.Get(r.db, dest, r.db.Rebind(query), args...)
+	if err != nil {
+		log.Debug(r.ctx, "Error querying "+r.tableName, "query", query, err)
+		return wrapNotFound(err)
+	}
+	return nil
+}
+
+func (r sqlRepository) queryAll(sel sq.SelectBuilder, dest interface{}) error {
+	query, args, err := sel.ToSql()
+	if err != nil {
+		return err
+	}
+	return sqlx.Select(r.db, dest, r.db.Rebind(query), args...)
+}
+
+func (r sqlRepository) count(sel sq.SelectBuilder) (int64, error) {
+	query, args, err := sel.Column("count(*) as count").ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = sqlx.Get(r.db, &count, r.db.Rebind(query), args...)
+	return count, err
+}
+
+func (r sqlRepository) executeSQL(upd sq.Sqlizer) (int64, error) {
+	query, args, err := upd.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.db.Exec(r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r sqlRepository) delete(cond sq.Sqlizer) error {
+	del := sq.Delete(r.tableName).Where(cond)
+	_, err := r.executeSQL(del)
+	return err
+}
+
+// put performs an upsert: it tries to update the row identified by id, and
+// falls back to an insert when no row was affected.
+func (r sqlRepository) put(id string, rec interface{}) error {
+	m := structs.Map(rec)
+	delete(m, "-")
+	upd := sq.Update(r.tableName).Where(sq.Eq{"id": id})
+	for k, v := range m {
+		upd = upd.Set(k, v)
+	}
+	affected, err := r.executeSQL(upd)
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	m["id"] = id
+	ins := sq.Insert(r.tableName).SetMap(m)
+	_, err = r.executeSQL(ins)
+	return err
+}
+
+func wrapNotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err.Error() == "sql: no rows in result set" {
+		return model.ErrNotFound
+	}
+	return err
+}
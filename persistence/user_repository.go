@@ -0,0 +1,233 @@
+package persistence
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/deluan/rest"
+	"github.com/jmoiron/sqlx"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/consts"
+	"github.com/navidrome/navidrome/model"
+)
+
+type userRepository struct {
+	sqlRepository
+}
+
+func NewUserRepository(ctx context.Context, db sqlx.Ext) model.UserRepository {
+	r := &userRepository{}
+	r.ctx = ctx
+	r.db = db
+	r.tableName = "user"
+	return r
+}
+
+func (r *userRepository) Get(id string) (*model.User, error) {
+	sel := r.newSelect().Where(sq.Eq{"id": id})
+	var u model.User
+	err := r.queryOne(sel, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepository) GetAll(options ...model.QueryOptions) (model.Users, error) {
+	sel := r.newSelect(options...)
+	var all model.Users
+	err := r.queryAll(sel, &all)
+	return all, err
+}
+
+func (r *userRepository) CountAll(options ...model.QueryOptions) (int64, error) {
+	return r.count(r.newSelect(options...))
+}
+
+func (r *userRepository) Delete(id string) error {
+	return r.delete(sq.Eq{"id": id})
+}
+
+func (r *userRepository) FindByUsername(username string) (*model.User, error) {
+	sel := r.newSelect().Where(sq.Eq{"user_name": strings.ToLower(username)})
+	var u model.User
+	err := r.queryOne(sel, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*model.User, error) {
+	sel := r.newSelect().Where(sq.Expr("lower(email) = ?", strings.ToLower(email)))
+	var u model.User
+	err := r.queryOne(sel, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// FindByUsernameWithPassword behaves like FindByUsername, but also decrypts the
+// stored password into User.Password so callers can compare it against a
+// submitted credential. It should only be used by the authentication paths.
+func (r *userRepository) FindByUsernameWithPassword(username string) (*model.User, error) {
+	u, err := r.FindByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	u.Password, err = decryptPassword(u.Password)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r *userRepository) UpdateLastLoginAt(id string) error {
+	now := time.Now()
+	upd := sq.Update(r.tableName).Set("last_login_at", now).Where(sq.Eq{"id": id})
+	_, err := r.executeSQL(upd)
+	return err
+}
+
+func (r *userRepository) UpdateLastAccessAt(id string) error {
+	now := time.Now()
+	upd := sq.Update(r.tableName).Set("last_access_at", now).Where(sq.Eq{"id": id})
+	_, err := r.executeSQL(upd)
+	return err
+}
+
+func (r *userRepository) Put(u *model.User) error {
+	var replacedPassword string
+	if u.NewPassword != "" {
+		existing, err := r.Get(u.ID)
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			replacedPassword = existing.Password
+		}
+		if err := r.checkPasswordHistory(u.ID, replacedPassword, u.NewPassword); err != nil {
+			return err
+		}
+		if err := checkPasswordPolicy(u, u.NewPassword); err != nil {
+			return err
+		}
+		hash, err := encryptPassword(u.NewPassword)
+		if err != nil {
+			return err
+		}
+		u.Password = hash
+	}
+	u.UserName = strings.ToLower(u.UserName)
+	u.UpdatedAt = time.Now()
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = u.UpdatedAt
+	}
+	if err := r.put(u.ID, u); err != nil {
+		return err
+	}
+	if replacedPassword != "" {
+		if err := r.recordPasswordHistory(u.ID, replacedPassword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encryptPassword(password string) (string, error) {
+	block, err := newPasswordCipher()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	cipherText := gcm.Seal(nonce, nonce, []byte(password), nil)
+	return string(cipherText), nil
+}
+
+func decryptPassword(encrypted string) (string, error) {
+	if encrypted == "" {
+		return "", nil
+	}
+	block, err := newPasswordCipher()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	data := []byte(encrypted)
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("malformed encrypted password")
+	}
+	nonce, cipherText := data[:nonceSize], data[nonceSize:]
+	plain, err := gcm.Open(nil, nonce, cipherText, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newPasswordCipher() (cipher.Block, error) {
+	key := []byte(conf.Server.PasswordEncryptionKey)
+	return aes.NewCipher(key)
+}
+
+// validatePasswordChange makes sure a password change requested as part of a
+// user Put is legitimate: regular users must confirm their current password,
+// admins can reset other users' passwords without it, and autogenerated
+// passwords (Reverse Proxy Authentication) never require confirmation.
+func validatePasswordChange(user *model.User, loggedUser *model.User) error {
+	if user.CurrentPassword == "" && user.NewPassword == "" {
+		return nil
+	}
+	if strings.HasPrefix(loggedUser.Password, consts.PasswordAutogenPrefix) {
+		return nil
+	}
+	errs := rest.ValidationErrors{}
+	if user.ID == loggedUser.ID {
+		if user.CurrentPassword == "" {
+			errs["currentPassword"] = "ra.validation.required"
+		} else if user.CurrentPassword != loggedUser.Password {
+			errs["currentPassword"] = "ra.validation.passwordDoesNotMatch"
+		} else if user.NewPassword == "" {
+			errs["password"] = "ra.validation.required"
+		}
+	}
+	if len(errs) > 0 {
+		return &rest.ValidationError{Errors: errs}
+	}
+	if user.NewPassword != "" {
+		if err := checkPasswordPolicy(user, user.NewPassword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateUsernameUnique(repo model.UserRepository, u *model.User) error {
+	existing, err := repo.FindByUsername(u.UserName)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != u.ID {
+		return &rest.ValidationError{Errors: rest.ValidationErrors{"userName": "ra.validation.unique"}}
+	}
+	return nil
+}
@@ -3,9 +3,11 @@ package persistence
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/deluan/rest"
 	"github.com/google/uuid"
+	"github.com/navidrome/navidrome/conf"
 	"github.com/navidrome/navidrome/consts"
 	"github.com/navidrome/navidrome/log"
 	"github.com/navidrome/navidrome/model"
@@ -235,4 +237,269 @@ var _ = Describe("UserRepository", func() {
 			Expect(err).To(MatchError("fake error"))
 		})
 	})
+
+	Describe("Password history", func() {
+		var histUser model.User
+
+		BeforeEach(func() {
+			// Reset the user to "firstpass" with history disabled, which also
+			// clears any history left by an earlier spec on the shared DB.
+			histUser = model.User{ID: "456", UserName: "histuser", NewPassword: "firstpass"}
+			Expect(repo.Put(&histUser)).To(BeNil())
+			conf.Server.PasswordPolicy.HistorySize = 2
+		})
+		AfterEach(func() {
+			conf.Server.PasswordPolicy.HistorySize = 0
+		})
+
+		It("rejects reusing the current password", func() {
+			histUser.NewPassword = "firstpass"
+			err := repo.Put(&histUser)
+			var verr *rest.ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(verr.Errors).To(HaveKeyWithValue("password", "ra.validation.password.reused"))
+		})
+
+		It("rejects reusing a password still within the history window", func() {
+			histUser.NewPassword = "secondpass"
+			Expect(repo.Put(&histUser)).To(BeNil())
+
+			histUser.NewPassword = "firstpass"
+			err := repo.Put(&histUser)
+			var verr *rest.ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(verr.Errors).To(HaveKeyWithValue("password", "ra.validation.password.reused"))
+		})
+
+		It("allows reuse once a password rotates out of the history window", func() {
+			histUser.NewPassword = "secondpass"
+			Expect(repo.Put(&histUser)).To(BeNil())
+			histUser.NewPassword = "thirdpass"
+			Expect(repo.Put(&histUser)).To(BeNil())
+
+			// History size is 2, so "firstpass" (the original password) should
+			// have rotated out by now.
+			histUser.NewPassword = "firstpass"
+			Expect(repo.Put(&histUser)).To(BeNil())
+		})
+
+		It("records history even when Put is called without a currentPassword, as an admin reset would", func() {
+			histUser.CurrentPassword = ""
+			histUser.NewPassword = "secondpass"
+			Expect(repo.Put(&histUser)).To(BeNil())
+
+			histUser.NewPassword = "firstpass"
+			err := repo.Put(&histUser)
+			var verr *rest.ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(verr.Errors).To(HaveKeyWithValue("password", "ra.validation.password.reused"))
+		})
+	})
+
+	Describe("Account lockout", func() {
+		lockUser := model.User{
+			ID:          "654",
+			UserName:    "lockme",
+			NewPassword: "correctpass",
+		}
+		BeforeEach(func() {
+			conf.Server.Auth.MaxFailedAttempts = 3
+			conf.Server.Auth.FailureWindow = time.Hour
+			conf.Server.Auth.MaxLockDuration = 10 * time.Minute
+			Expect(repo.Put(&lockUser)).To(BeNil())
+		})
+
+		It("locks the account after MaxFailedAttempts, with exponentially increasing duration", func() {
+			for i := 0; i < 3; i++ {
+				_, err := repo.AuthenticateByUsername("lockme", "wrongpass")
+				Expect(err).To(HaveOccurred())
+			}
+			locked, until := repo.IsLocked(lockUser.ID)
+			Expect(locked).To(BeTrue())
+			Expect(until).To(BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+
+			// Clear the lock so login attempts aren't rejected outright, then
+			// cross the threshold twice more: the streak escalates from
+			// scratch, and attempts made while newly locked still register,
+			// so the second lockout doubles the first's duration.
+			Expect(repo.ClearFailedLogins(lockUser.ID)).ToNot(HaveOccurred())
+			for i := 0; i < 6; i++ {
+				_, err := repo.AuthenticateByUsername("lockme", "wrongpass")
+				Expect(err).To(HaveOccurred())
+			}
+			_, until = repo.IsLocked(lockUser.ID)
+			Expect(until).To(BeTemporally("~", time.Now().Add(2*time.Minute), 5*time.Second))
+		})
+
+		It("caps the lockout duration at MaxLockDuration", func() {
+			conf.Server.Auth.MaxLockDuration = 90 * time.Second
+			for i := 0; i < 9; i++ {
+				_, _ = repo.AuthenticateByUsername("lockme", "wrongpass")
+			}
+			_, until := repo.IsLocked(lockUser.ID)
+			Expect(until).To(BeTemporally("~", time.Now().Add(90*time.Second), 5*time.Second))
+		})
+
+		It("resets the counter once the failure window has passed", func() {
+			Expect(repo.RegisterFailedLogin(lockUser.ID)).ToNot(HaveOccurred())
+			Expect(repo.RegisterFailedLogin(lockUser.ID)).ToNot(HaveOccurred())
+
+			conf.Server.Auth.FailureWindow = time.Millisecond
+			time.Sleep(5 * time.Millisecond)
+
+			// A third failure arrives well after the window: it should start
+			// a fresh count of 1, not reach the lockout threshold of 3.
+			Expect(repo.RegisterFailedLogin(lockUser.ID)).ToNot(HaveOccurred())
+			locked, _ := repo.IsLocked(lockUser.ID)
+			Expect(locked).To(BeFalse())
+		})
+
+		It("clears the lockout state after a successful login", func() {
+			_, err := repo.AuthenticateByUsername("lockme", "wrongpass")
+			Expect(err).To(HaveOccurred())
+
+			_, err = repo.AuthenticateByUsername("lockme", "correctpass")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.AuthenticateByUsername("lockme", "wrongpass")
+			Expect(err).To(HaveOccurred())
+			_, err = repo.AuthenticateByUsername("lockme", "wrongpass")
+			Expect(err).To(HaveOccurred())
+			locked, _ := repo.IsLocked(lockUser.ID)
+			Expect(locked).To(BeFalse(), "the earlier successful login should have reset the failure count")
+		})
+
+		It("rejects the correct password while the account is locked", func() {
+			for i := 0; i < 3; i++ {
+				_, _ = repo.AuthenticateByUsername("lockme", "wrongpass")
+			}
+			_, err := repo.AuthenticateByUsername("lockme", "correctpass")
+			Expect(err).To(MatchError(model.ErrAccountLocked))
+		})
+	})
+
+	Describe("AuthenticateByUsername", func() {
+		authUser := model.User{
+			ID:          "789",
+			UserName:    "authuser",
+			NewPassword: "correcthorse",
+		}
+		BeforeEach(func() {
+			Expect(repo.Put(&authUser)).To(BeNil())
+		})
+
+		It("returns the user on a correct password", func() {
+			u, err := repo.AuthenticateByUsername("authuser", "correcthorse")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.ID).To(Equal("789"))
+		})
+
+		It("returns ErrInvalidCredentials on a wrong password", func() {
+			_, err := repo.AuthenticateByUsername("authuser", "wrongpassword")
+			Expect(err).To(MatchError(model.ErrInvalidCredentials))
+		})
+
+		It("returns ErrInvalidCredentials for an unknown username", func() {
+			_, err := repo.AuthenticateByUsername("nosuchuser", "whatever")
+			Expect(err).To(MatchError(model.ErrInvalidCredentials))
+		})
+
+		It("takes roughly the same time for an unknown username as for a wrong password", func() {
+			const samples = 20
+			unknown := measureAvg(samples, func() {
+				_, _ = repo.AuthenticateByUsername("nosuchuser", "whatever")
+			})
+			wrongPass := measureAvg(samples, func() {
+				_, _ = repo.AuthenticateByUsername("authuser", "wrongpassword")
+			})
+			// This is a coarse sanity check, not a precise timing-attack test:
+			// it just guards against the unknown-username path taking an
+			// order of magnitude less time (e.g. short-circuiting before any
+			// decrypt/compare work happens).
+			ratio := float64(unknown) / float64(wrongPass)
+			Expect(ratio).To(BeNumerically(">", 0.2))
+			Expect(ratio).To(BeNumerically("<", 5))
+		})
+
+		Context("when Auth.IgnoreUnknownUsernames is disabled", func() {
+			BeforeEach(func() {
+				conf.Server.Auth.IgnoreUnknownUsernames = false
+			})
+			AfterEach(func() {
+				conf.Server.Auth.IgnoreUnknownUsernames = true
+			})
+			It("reveals that the username is unknown", func() {
+				_, err := repo.AuthenticateByUsername("nosuchuser", "whatever")
+				Expect(err).To(MatchError(model.ErrNotFound))
+			})
+		})
+	})
+
+	Describe("Password reset tokens", func() {
+		usr := model.User{
+			ID:          "321",
+			UserName:    "resetme",
+			Name:        "Reset Me",
+			Email:       "resetme@example.com",
+			NewPassword: "oldpass",
+		}
+		BeforeEach(func() {
+			Expect(repo.Put(&usr)).To(BeNil())
+		})
+
+		It("issues a token that resolves back to the user", func() {
+			token, err := repo.CreatePasswordResetToken(usr.ID, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).ToNot(BeEmpty())
+
+			resolved, err := repo.ConsumePasswordResetToken(token)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolved.ID).To(Equal(usr.ID))
+		})
+
+		It("rejects the token once it has been consumed", func() {
+			token, err := repo.CreatePasswordResetToken(usr.ID, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.ConsumePasswordResetToken(token)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.ConsumePasswordResetToken(token)
+			Expect(err).To(MatchError(model.ErrInvalidResetToken))
+		})
+
+		It("rejects an expired token", func() {
+			token, err := repo.CreatePasswordResetToken(usr.ID, -time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.ConsumePasswordResetToken(token)
+			Expect(err).To(MatchError(model.ErrInvalidResetToken))
+		})
+
+		It("invalidates a previously issued token when a new one is requested", func() {
+			first, err := repo.CreatePasswordResetToken(usr.ID, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.CreatePasswordResetToken(usr.ID, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = repo.ConsumePasswordResetToken(first)
+			Expect(err).To(MatchError(model.ErrInvalidResetToken))
+		})
+
+		It("rejects an unknown token", func() {
+			_, err := repo.ConsumePasswordResetToken("not-a-real-token")
+			Expect(err).To(MatchError(model.ErrInvalidResetToken))
+		})
+	})
 })
+
+// measureAvg runs fn `samples` times and returns the average wall-clock
+// duration per call.
+func measureAvg(samples int, fn func()) time.Duration {
+	start := time.Now()
+	for i := 0; i < samples; i++ {
+		fn()
+	}
+	return time.Since(start) / time.Duration(samples)
+}
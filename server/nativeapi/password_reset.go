@@ -0,0 +1,119 @@
+package nativeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/deluan/rest"
+	"github.com/go-chi/chi/v5"
+	"github.com/navidrome/navidrome/conf"
+	"github.com/navidrome/navidrome/core"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+type passwordResetRequestPayload struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type passwordResetConfirmPayload struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"password"`
+}
+
+// addPasswordResetRoutes wires up the self-service password reset endpoints.
+// The reset-request handler answers 200 with an empty body whether or not the
+// username/email matches an account, so its response can't be used to
+// enumerate accounts. Its running time is not equalized: for a matching
+// account it also creates a token and calls the notifier.
+func addPasswordResetRoutes(r chi.Router, ds model.DataStore, notifier core.PasswordResetNotifier) {
+	r.Post("/user/password/reset-request", passwordResetRequestHandler(ds, notifier))
+	r.Post("/user/password/reset-confirm", passwordResetConfirmHandler(ds))
+}
+
+func passwordResetRequestHandler(ds model.DataStore, notifier core.PasswordResetNotifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		var payload passwordResetRequestPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		username := strings.TrimSpace(payload.Username)
+		email := strings.TrimSpace(payload.Email)
+		if username == "" && email == "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		repo := ds.User(ctx)
+		var usr *model.User
+		var err error
+		if username != "" {
+			usr, err = repo.FindByUsername(username)
+		} else {
+			usr, err = repo.FindByEmail(email)
+		}
+		if err != nil {
+			log.Debug(ctx, "Password reset requested for unknown user", "username", payload.Username, "email", payload.Email)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		token, err := repo.CreatePasswordResetToken(usr.ID, conf.Server.Auth.PasswordResetTokenTTL)
+		if err != nil {
+			log.Error(ctx, "Could not create password reset token", err)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		link := fmt.Sprintf("%s/app/#/reset-password?token=%s", strings.TrimSuffix(conf.Server.BaseURL, "/"), token)
+		if err := notifier.Notify(ctx, usr.Email, link); err != nil {
+			log.Error(ctx, "Could not send password reset email", "user", usr.UserName, err)
+		}
+
+		// Always respond with 200, regardless of whether the account exists
+		// or the email could be sent, to avoid leaking which usernames/emails
+		// are registered.
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func passwordResetConfirmHandler(ds model.DataStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		var payload passwordResetConfirmPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		repo := ds.User(ctx)
+		usr, err := repo.ConsumePasswordResetToken(payload.Token)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid or expired token"})
+			return
+		}
+
+		usr.NewPassword = payload.NewPassword
+		if err := repo.Put(usr); err != nil {
+			var verr *rest.ValidationError
+			if errors.As(err, &verr) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(verr.Errors)
+				return
+			}
+			log.Error(ctx, "Could not set new password after reset", "user", usr.UserName, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,31 @@
+package nativeapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/navidrome/navidrome/log"
+	"github.com/navidrome/navidrome/model"
+)
+
+// addUserUnlockRoute wires up the admin-only endpoint used to clear a user's
+// failed-login lockout, for when they've been throttled out by
+// RegisterFailedLogin and can't just wait it out.
+func addUserUnlockRoute(r chi.Router, ds model.DataStore) {
+	r.Post("/user/{id}/unlock", userUnlockHandler(ds))
+}
+
+func userUnlockHandler(ds model.DataStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		id := chi.URLParam(req, "id")
+
+		repo := ds.User(ctx)
+		if err := repo.ClearFailedLogins(id); err != nil {
+			log.Error(ctx, "Could not clear lockout", "userId", id, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
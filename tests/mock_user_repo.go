@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"strings"
+
+	"github.com/navidrome/navidrome/model"
+)
+
+type MockedUserRepo struct {
+	model.UserRepository
+	Error error
+	data  map[string]*model.User
+}
+
+func CreateMockUserRepo() *MockedUserRepo {
+	return &MockedUserRepo{data: map[string]*model.User{}}
+}
+
+func (m *MockedUserRepo) Put(u *model.User) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	m.data[u.ID] = u
+	return nil
+}
+
+func (m *MockedUserRepo) Get(id string) (*model.User, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	if u, ok := m.data[id]; ok {
+		return u, nil
+	}
+	return nil, model.ErrNotFound
+}
+
+func (m *MockedUserRepo) FindByUsername(username string) (*model.User, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	for _, u := range m.data {
+		if strings.EqualFold(u.UserName, username) {
+			return u, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (m *MockedUserRepo) FindByEmail(email string) (*model.User, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	for _, u := range m.data {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
@@ -0,0 +1,9 @@
+package tests
+
+import "testing"
+
+// Init is called by the Ginkgo suites before they run. It only marks the
+// caller as a test helper; the inMemoryDB argument is not used.
+func Init(t *testing.T, inMemoryDB bool) {
+	t.Helper()
+}